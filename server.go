@@ -27,68 +27,289 @@
 package tstsrv
 
 import (
+	"bytes"
+	cryptorand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"regexp"
 	"strings"
 	"sync"
+	"text/template"
+	"time"
 )
 
 // Server wraps Go's built-in "httptest.Server" but provides an API for configuring the responses.
 type Server struct {
-	httpServer *httptest.Server             // The actual "httptest.Server".
-	routes     map[string]RespConfiguration // The map of routes and their configuration.
-	lock       sync.Mutex                   // Protect concurrent access to call counts.
+	httpServer    *httptest.Server               // The actual "httptest.Server".
+	routes        map[string][]RespConfiguration // The map of routes and their configuration(s).
+	requests      []RecordedRequest              // Every request received so far, in arrival order.
+	globalLatency time.Duration                  // A minimum delay applied to every response.
+	lock          sync.Mutex                     // Protect concurrent access to call counts and recorded requests.
+}
+
+// RecordedRequest is a snapshot of a single request received by a Server, taken at the time it was handled.
+type RecordedRequest struct {
+	Method           string              // The HTTP method of the request.
+	URL              string              // The complete URL (including the query string) that was requested.
+	Headers          http.Header         // The headers sent with the request.
+	Body             []byte              // The body sent with the request.
+	Timestamp        time.Time           // The time at which the request was handled.
+	Served           *Response           // The response that was served, or nil if no configured response matched.
+	PeerCertificates []*x509.Certificate // The client certificate chain presented over TLS, if any.
 }
 
 // RespConfiguration is the configuration for a Server.
+//
+// A path may have several RespConfiguration entries attached to it. When a request comes in, the first
+// RespConfiguration whose matchers (Method, HeaderMatch, BodyContains, BodyRegex) are all satisfied is used to
+// serve the response. Leaving a matcher at its zero value means "match anything".
 type RespConfiguration struct {
+	Method       string            // The HTTP method to match. Empty matches any method.
+	HeaderMatch  map[string]string // Header values that must all be present (exact match) on the request.
+	BodyContains string            // A substring that must occur in the request body.
+	BodyRegex    string            // A regular expression the request body must match.
+
 	Responses []Response // A sequence of HTTP responses to return.
 	callCount int        // Counter to track the number of calls.
+
+	bodyTemplates []*template.Template // Response.Body, parsed as a template, one per entry in Responses.
 }
 
 // Response represents the response to an HTTP request.
+//
+// Body is executed as a "text/template" template before being written, with a TemplateContext as its data. This
+// allows a single configured response to echo back request details or produce dynamic content instead of users
+// having to enumerate every distinct response variant. A Body without template actions behaves exactly as a
+// plain string.
 type Response struct {
-	StatusCode     int    // The HTTP status code to return.
-	Body           string // The body to return.
-	DropConnection bool   // Drop the connection. This is to simulate that the body can't be read.
+	StatusCode      int           // The HTTP status code to return.
+	Body            string        // The (templated) body to return.
+	DropConnection  bool          // Drop the connection. This is to simulate that the body can't be read.
+	Delay           time.Duration // A delay to apply before the response is written, simulating a slow server.
+	ResetConnection bool          // Hijack the connection and reset it (RST), simulating a network-level failure.
+	RetryAfter      string        // When set, the value to send in the "Retry-After" header (e.g. for 429/503).
+}
+
+// TemplateContext is the data made available to a Response.Body template.
+type TemplateContext struct {
+	ServerURL string          // The base URL of the server.
+	Request   TemplateRequest // Details of the incoming request.
+	CallCount int             // The 1-based number of times this response sequence has been invoked.
+}
+
+// TemplateRequest exposes the parts of the incoming request available to a Response.Body template.
+type TemplateRequest struct {
+	Method  string      // The HTTP method of the request.
+	Path    string      // The path of the request, without the query string.
+	Query   url.Values  // The parsed query string of the request.
+	Headers http.Header // The headers sent with the request.
+}
+
+// templateFuncs are the helper functions made available to every Response.Body template.
+var templateFuncs = template.FuncMap{
+	"uuid": newUUID,
+	"now":  func() string { return time.Now().UTC().Format(time.RFC3339Nano) },
+	"jsonEscape": func(s string) string {
+		escaped, _ := json.Marshal(s)
+
+		return string(escaped[1 : len(escaped)-1])
+	},
+}
+
+// newUUID returns a random (version 4) UUID, for use by the "uuid" template helper.
+func newUUID() string {
+	var b [16]byte
+
+	_, _ = cryptorand.Read(b[:])
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
 }
 
 // New returns a new Server with the given routes.
-func New(routes map[string]RespConfiguration) *Server {
+func New(routes map[string][]RespConfiguration) *Server {
+	return newServer(routes, false)
+}
+
+// NewTLS returns a new Server with the given routes, backed by an HTTPS listener using a self-signed
+// certificate. Use Server.Client to get an *http.Client that trusts it.
+func NewTLS(routes map[string][]RespConfiguration) *Server {
+	return newServer(routes, true)
+}
+
+// Builds and starts the Server backing routes, either as a plain HTTP server or, when tlsMode is true, as an
+// HTTPS server.
+func newServer(routes map[string][]RespConfiguration, tlsMode bool) *Server {
+	if routes == nil {
+		routes = map[string][]RespConfiguration{}
+	}
+
 	server := &Server{
 		routes: routes,
 	}
 
 	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Read the body before taking server.lock: a slow or trickling client would otherwise hold the lock for
+		// as long as its upload takes, stalling every other route (and Requests()/Reset()) in the meantime.
+		requestUri := rawUrl(r)
+		body, _ := io.ReadAll(r.Body)
+
+		var peerCertificates []*x509.Certificate
+
+		if r.TLS != nil {
+			peerCertificates = r.TLS.PeerCertificates
+		}
+
 		server.lock.Lock()
-		defer server.lock.Unlock()
 
-		requestUri := rawUrl(r)
+		configs, match := server.routes[requestUri]
+		idx := -1
+
+		for i := range configs {
+			if matches(configs[i], r, body) {
+				idx = i
+
+				break
+			}
+		}
+
+		if !match || idx == -1 || configs[idx].callCount >= len(configs[idx].Responses) {
+			server.requests = append(server.requests, RecordedRequest{
+				Method:           r.Method,
+				URL:              requestUri,
+				Headers:          r.Header,
+				Body:             body,
+				Timestamp:        time.Now(),
+				PeerCertificates: peerCertificates,
+			})
+
+			latency := server.globalLatency
+
+			server.lock.Unlock()
+
+			if latency > 0 {
+				time.Sleep(latency)
+			}
 
-		if routeConfig, match := server.routes[requestUri]; !match || routeConfig.callCount >= len(routeConfig.Responses) {
 			w.WriteHeader(http.StatusNotImplemented)
 
 			return
-		} else {
-			response := routeConfig.Responses[routeConfig.callCount]
-			routeConfig.callCount++
-			server.routes[requestUri] = routeConfig
+		}
+
+		rc := &configs[idx]
+		callIndex := rc.callCount
+		response := rc.Responses[callIndex]
+		rc.callCount++
+		delay := server.globalLatency + response.Delay
+
+		if len(rc.bodyTemplates) != len(rc.Responses) {
+			grown := make([]*template.Template, len(rc.Responses))
+			copy(grown, rc.bodyTemplates)
+			rc.bodyTemplates = grown
+		}
+
+		var renderErr error
 
-			w.WriteHeader(response.StatusCode)
+		if rc.bodyTemplates[callIndex] == nil {
+			parsed, err := template.New("body").Funcs(templateFuncs).Parse(response.Body)
 
-			if response.DropConnection {
-				conn, _, _ := w.(http.Hijacker).Hijack()
-				conn.Close()
+			if err != nil {
+				renderErr = err
 			} else {
-				response.Body = strings.Replace(response.Body, "$$URI$$", server.httpServer.URL, -1)
+				rc.bodyTemplates[callIndex] = parsed
+			}
+		}
 
-				w.Write([]byte(response.Body))
+		if tmpl := rc.bodyTemplates[callIndex]; renderErr == nil && tmpl != nil {
+			var rendered bytes.Buffer
+
+			if err := tmpl.Execute(&rendered, TemplateContext{
+				ServerURL: server.httpServer.URL,
+				Request: TemplateRequest{
+					Method:  r.Method,
+					Path:    r.URL.Path,
+					Query:   r.URL.Query(),
+					Headers: r.Header,
+				},
+				CallCount: callIndex + 1,
+			}); err != nil {
+				renderErr = err
+			} else {
+				response.Body = rendered.String()
 			}
 		}
+
+		// A broken Response.Body template should fail loudly rather than silently serve the raw, unexecuted
+		// template source - a user debugging a misconfigured fixture needs to see this immediately.
+		if renderErr != nil {
+			response.StatusCode = http.StatusInternalServerError
+			response.Body = fmt.Sprintf("tstsrv: error rendering Response.Body template: %v", renderErr)
+			response.DropConnection = false
+			response.ResetConnection = false
+			response.RetryAfter = ""
+		}
+
+		server.requests = append(server.requests, RecordedRequest{
+			Method:           r.Method,
+			URL:              requestUri,
+			Headers:          r.Header,
+			Body:             body,
+			Timestamp:        time.Now(),
+			Served:           &response,
+			PeerCertificates: peerCertificates,
+		})
+
+		server.lock.Unlock()
+
+		if delay > 0 {
+			time.Sleep(delay)
+		}
+
+		if response.ResetConnection {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+
+			if tcpConn, ok := conn.(*net.TCPConn); ok {
+				tcpConn.SetLinger(0)
+			}
+
+			conn.Close()
+
+			return
+		}
+
+		if response.RetryAfter != "" {
+			w.Header().Set("Retry-After", response.RetryAfter)
+		}
+
+		w.WriteHeader(response.StatusCode)
+
+		if response.DropConnection {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		} else {
+			w.Write([]byte(response.Body))
+		}
 	})
 
-	server.httpServer = httptest.NewServer(handler)
+	if tlsMode {
+		server.httpServer = httptest.NewUnstartedServer(handler)
+
+		// Request (but don't require) a client certificate, so mTLS-style clients can be exercised and
+		// RecordedRequest.PeerCertificates is actually populated when one is presented.
+		server.httpServer.TLS = &tls.Config{ClientAuth: tls.RequestClientCert}
+		server.httpServer.StartTLS()
+	} else {
+		server.httpServer = httptest.NewServer(handler)
+	}
 
 	return server
 }
@@ -103,6 +324,154 @@ func (f *Server) URL() string {
 	return f.httpServer.URL
 }
 
+// Client returns an *http.Client configured to talk to the server. In TLS mode (see NewTLS), the client is
+// pre-configured to trust the server's self-signed certificate.
+func (f *Server) Client() *http.Client {
+	return f.httpServer.Client()
+}
+
+// SetGlobalLatency sets a minimum delay applied to every response served by the server, in addition to any
+// per-response Response.Delay.
+func (f *Server) SetGlobalLatency(d time.Duration) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.globalLatency = d
+}
+
+// Requests returns every request received by the server so far, in the order they arrived.
+func (f *Server) Requests() []RecordedRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	requests := make([]RecordedRequest, len(f.requests))
+	copy(requests, f.requests)
+
+	return requests
+}
+
+// RequestsFor returns every request received for the given path (including the query string), in the order
+// they arrived.
+func (f *Server) RequestsFor(path string) []RecordedRequest {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var requests []RecordedRequest
+
+	for _, request := range f.requests {
+		if request.URL == path {
+			requests = append(requests, request)
+		}
+	}
+
+	return requests
+}
+
+// Reset clears the recorded requests.
+func (f *Server) Reset() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.requests = nil
+}
+
+// When starts building a route for path. The route is added to the server's routes as soon as a response is
+// registered via RespondWith or Then, and can keep growing after that by chaining further calls to Then -
+// including after New has returned, e.g. once a value produced by an earlier request (such as a token) becomes
+// known.
+func (f *Server) When(path string) *ResponseBuilder {
+	return &ResponseBuilder{server: f, path: path}
+}
+
+// Clear removes every route configured for path.
+func (f *Server) Clear(path string) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	delete(f.routes, path)
+}
+
+// ClearAll removes every configured route.
+func (f *Server) ClearAll() {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	f.routes = map[string][]RespConfiguration{}
+}
+
+// ResponseBuilder fluently builds a RespConfiguration for a path given to Server.When.
+type ResponseBuilder struct {
+	server *Server
+	path   string
+	config RespConfiguration
+
+	registered bool // Whether config has already been appended to server.routes.
+	index      int  // The index of config within server.routes[path], once registered.
+}
+
+// Method restricts the route to requests using the given HTTP method.
+func (b *ResponseBuilder) Method(method string) *ResponseBuilder {
+	b.config.Method = method
+
+	return b
+}
+
+// Header restricts the route to requests carrying the given header with the given (exact) value.
+func (b *ResponseBuilder) Header(key string, value string) *ResponseBuilder {
+	if b.config.HeaderMatch == nil {
+		b.config.HeaderMatch = map[string]string{}
+	}
+
+	b.config.HeaderMatch[key] = value
+
+	return b
+}
+
+// RespondWith registers response as the first response served for this route.
+func (b *ResponseBuilder) RespondWith(response Response) *ResponseBuilder {
+	b.config.Responses = append(b.config.Responses, response)
+	b.register()
+
+	return b
+}
+
+// Then appends response as the next response served for this route, once the previously registered responses
+// have all been served.
+func (b *ResponseBuilder) Then(response Response) *ResponseBuilder {
+	b.config.Responses = append(b.config.Responses, response)
+	b.register()
+
+	return b
+}
+
+// register writes b's RespConfiguration into the server's routes, under the server's lock. If the route was
+// previously registered but its path was since wiped by Server.Clear/Server.ClearAll, b is re-appended as a
+// fresh entry rather than indexing into a route that no longer exists.
+func (b *ResponseBuilder) register() {
+	b.server.lock.Lock()
+	defer b.server.lock.Unlock()
+
+	if b.server.routes == nil {
+		b.server.routes = map[string][]RespConfiguration{}
+	}
+
+	existing := b.server.routes[b.path]
+
+	if b.registered && b.index < len(existing) {
+		b.config.callCount = existing[b.index].callCount
+		b.config.bodyTemplates = existing[b.index].bodyTemplates
+		existing[b.index] = b.config
+
+		return
+	}
+
+	b.config.callCount = 0
+	b.config.bodyTemplates = nil
+	b.server.routes[b.path] = append(existing, b.config)
+	b.index = len(b.server.routes[b.path]) - 1
+	b.registered = true
+}
+
 // Returns the complete URL (including the query string) of r.
 func rawUrl(r *http.Request) string {
 	if r.URL.RawQuery != "" {
@@ -111,3 +480,30 @@ func rawUrl(r *http.Request) string {
 
 	return r.URL.Path
 }
+
+// Returns whether rc's matchers are all satisfied by r and its (already read) body.
+func matches(rc RespConfiguration, r *http.Request, body []byte) bool {
+	if rc.Method != "" && !strings.EqualFold(rc.Method, r.Method) {
+		return false
+	}
+
+	for header, value := range rc.HeaderMatch {
+		if r.Header.Get(header) != value {
+			return false
+		}
+	}
+
+	if rc.BodyContains != "" && !strings.Contains(string(body), rc.BodyContains) {
+		return false
+	}
+
+	if rc.BodyRegex != "" {
+		matched, err := regexp.MatchString(rc.BodyRegex, string(body))
+
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	return true
+}
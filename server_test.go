@@ -27,9 +27,18 @@
 package tstsrv_test
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"io"
+	"math/big"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-essentials/assert"
 	"github.com/go-essentials/tstsrv"
@@ -40,12 +49,14 @@ func TestServer(t *testing.T) {
 	t.Parallel() // Enable parallel execution.
 
 	// FAKE SETUP.
-	srvFake := tstsrv.New(map[string]tstsrv.RespConfiguration{
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
 		"/test?v=10": {
-			Responses: []tstsrv.Response{
-				{StatusCode: http.StatusOK, Body: "response 1"},
-				{StatusCode: http.StatusCreated, Body: "response 2"},
-				{StatusCode: http.StatusOK, DropConnection: true},
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "response 1"},
+					{StatusCode: http.StatusCreated, Body: "response 2"},
+					{StatusCode: http.StatusOK, DropConnection: true},
+				},
 			},
 		},
 	})
@@ -171,3 +182,683 @@ func TestServer(t *testing.T) {
 		resp.Body.Close()
 	})
 }
+
+// UT: Route matching considers the HTTP method and headers of the incoming request.
+func TestServer_MethodAndHeaderMatching(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
+		"/resource": {
+			{
+				Method: http.MethodPost,
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusCreated, Body: "created"},
+				},
+			},
+			{
+				Method:      http.MethodGet,
+				HeaderMatch: map[string]string{"Accept": "application/json"},
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: `{"ok":true}`},
+				},
+			},
+			{
+				Method: http.MethodGet,
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "plain"},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+
+	// SCENARIO #1.
+	t.Run("A POST request is routed to the POST-only configuration.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Post(srvURL+"/resource", "text/plain", nil)
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The POST request should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, resp.StatusCode, http.StatusCreated, "\n\n"+
+			"UT Name:  The POST request should return the 201 status code.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", http.StatusCreated, resp.StatusCode)
+
+		resp.Body.Close()
+	})
+
+	// SCENARIO #2.
+	t.Run("A GET request with a matching header is routed before the header-agnostic GET configuration.", func(t *testing.T) {
+		// ARRANGE.
+		req, _ := http.NewRequest(http.MethodGet, srvURL+"/resource", nil)
+		req.Header.Set("Accept", "application/json")
+
+		// ACT & ASSERT.
+		resp, err := http.DefaultClient.Do(req)
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The GET request with a matching header should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), `{"ok":true}`, "\n\n"+
+			"UT Name:  The response body should match the JSON configuration.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", `{"ok":true}`, string(body))
+	})
+
+	// SCENARIO #3.
+	t.Run("A GET request without the matching header falls through to the header-agnostic configuration.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Get(srvURL + "/resource")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The GET request without the header should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), "plain", "\n\n"+
+			"UT Name:  The response body should match the fallback configuration.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "plain", string(body))
+	})
+}
+
+// UT: The server records every incoming request so tests can assert on what was sent.
+func TestServer_RequestRecording(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
+		"/orders": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "order 1"},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+
+	// SCENARIO #1.
+	t.Run("Requests() reflects every request served, in arrival order.", func(t *testing.T) {
+		// ACT.
+		resp1, _ := http.Post(srvURL+"/orders", "text/plain", strings.NewReader("first"))
+		resp1.Body.Close()
+
+		resp2, _ := http.Get(srvURL + "/unknown")
+		resp2.Body.Close()
+
+		requests := srvFake.Requests()
+
+		// ASSERT.
+		assert.Equalf(t, len(requests), 2, "\n\n"+
+			"UT Name:  Requests() should return one entry per request received.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", 2, len(requests))
+
+		assert.Equalf(t, requests[0].Method, http.MethodPost, "\n\n"+
+			"UT Name:  The first recorded request should carry the method it was sent with.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", http.MethodPost, requests[0].Method)
+
+		assert.Equalf(t, string(requests[0].Body), "first", "\n\n"+
+			"UT Name:  The first recorded request should carry the body it was sent with.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "first", string(requests[0].Body))
+
+		assert.NotNilf(t, requests[0].Served, "\n\n"+
+			"UT Name:  The first recorded request should carry the response that was served.\n"+
+			"\033[32mExpected: NOT <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", requests[0].Served)
+
+		// NOTE: requests[1].Served is a *tstsrv.Response, so comparing it via assert.Nilf would box it into an
+		// `any` with a concrete type and a nil value - a non-nil interface that never compares equal to the
+		// untyped nil assert.Nilf checks against. Compare the concrete pointer to nil directly instead.
+		assert.Equalf(t, requests[1].Served == nil, true, "\n\n"+
+			"UT Name:  The second recorded request (unmatched) should carry no served response.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", requests[1].Served)
+	})
+
+	// SCENARIO #2.
+	t.Run("RequestsFor() filters by path.", func(t *testing.T) {
+		// ACT & ASSERT.
+		requests := srvFake.RequestsFor("/orders")
+
+		assert.Equalf(t, len(requests), 1, "\n\n"+
+			"UT Name:  RequestsFor() should only return requests for the given path.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", 1, len(requests))
+	})
+
+	// SCENARIO #3.
+	t.Run("Reset() clears the recorded requests.", func(t *testing.T) {
+		// ACT.
+		srvFake.Reset()
+
+		// ASSERT.
+		assert.Equalf(t, len(srvFake.Requests()), 0, "\n\n"+
+			"UT Name:  Reset() should leave no recorded requests behind.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", 0, len(srvFake.Requests()))
+	})
+}
+
+// UT: Responses can simulate latency and carry a "Retry-After" header.
+func TestServer_LatencyAndRetryAfter(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
+		"/slow": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "response 1", Delay: 20 * time.Millisecond},
+				},
+			},
+		},
+		"/throttled": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusTooManyRequests, RetryAfter: "5"},
+				},
+			},
+		},
+		"/reset": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "unreachable", ResetConnection: true},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+	srvFake.SetGlobalLatency(10 * time.Millisecond)
+
+	// SCENARIO #1.
+	t.Run("A response with a Delay is served after (at least) that delay.", func(t *testing.T) {
+		// ACT.
+		start := time.Now()
+		resp, err := http.Get(srvURL + "/slow")
+		elapsed := time.Since(start)
+
+		// ASSERT.
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The delayed response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		resp.Body.Close()
+
+		assert.Equalf(t, elapsed >= 30*time.Millisecond, true, "\n\n"+
+			"UT Name:  The delayed response should take at least the global latency plus its own Delay.\n"+
+			"\033[32mExpected: >= %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", 30*time.Millisecond, elapsed)
+	})
+
+	// SCENARIO #2.
+	t.Run("A response with a RetryAfter carries the Retry-After header.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Get(srvURL + "/throttled")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The throttled response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, resp.StatusCode, http.StatusTooManyRequests, "\n\n"+
+			"UT Name:  The throttled response should return the 429 status code.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", http.StatusTooManyRequests, resp.StatusCode)
+
+		assert.Equalf(t, resp.Header.Get("Retry-After"), "5", "\n\n"+
+			"UT Name:  The throttled response should carry the configured Retry-After header.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "5", resp.Header.Get("Retry-After"))
+
+		resp.Body.Close()
+	})
+
+	// SCENARIO #3.
+	t.Run("A response with ResetConnection fails the request at the transport level, never a normal HTTP response.", func(t *testing.T) {
+		// ARRANGE.
+		// A dedicated, non-keep-alive client is required here: if this request reused a pooled connection left
+		// idle by an earlier scenario, net/http's Transport would silently retry the reset request on a fresh
+		// connection and never surface the failure to the caller.
+		client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+		// ACT.
+		resp, err := client.Get(srvURL + "/reset")
+
+		// ASSERT.
+		assert.Equalf(t, err != nil, true, "\n\n"+
+			"UT Name:  The reset response should fail the request with a transport error, not return a normal response.\n"+
+			"\033[32mExpected: non-nil error\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+	})
+}
+
+// UT: NewTLS serves over HTTPS, and Client() returns a client that trusts its certificate.
+func TestServer_NewTLS(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.NewTLS(map[string][]tstsrv.RespConfiguration{
+		"/secure": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: "secure response"},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+
+	// SCENARIO #1.
+	t.Run("The server URL uses the HTTPS scheme.", func(t *testing.T) {
+		// ACT & ASSERT.
+		assert.Equalf(t, strings.HasPrefix(srvURL, "https://"), true, "\n\n"+
+			"UT Name:  A TLS server should expose an HTTPS URL.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "https://...", srvURL)
+	})
+
+	// SCENARIO #2.
+	t.Run("Client() trusts the server's self-signed certificate.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := srvFake.Client().Get(srvURL + "/secure")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  A request made with Client() should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), "secure response", "\n\n"+
+			"UT Name:  The response body should match the configured response.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "secure response", string(body))
+	})
+
+	// SCENARIO #3.
+	t.Run("A client certificate presented by the caller is recorded on the RecordedRequest.", func(t *testing.T) {
+		// ARRANGE.
+		srvFake.Reset()
+
+		clientCert := generateSelfSignedClientCert(t)
+
+		client := srvFake.Client()
+		transport := client.Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+		client.Transport = transport
+
+		// ACT.
+		resp, err := client.Get(srvURL + "/secure")
+
+		// ASSERT.
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  A request presenting a client certificate should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		resp.Body.Close()
+
+		requests := srvFake.Requests()
+
+		assert.Equalf(t, len(requests), 1, "\n\n"+
+			"UT Name:  Exactly one request should have been recorded.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", 1, len(requests))
+
+		assert.Equalf(t, len(requests[0].PeerCertificates), 1, "\n\n"+
+			"UT Name:  The recorded request should carry the client certificate that was presented.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", 1, len(requests[0].PeerCertificates))
+	})
+}
+
+// generateSelfSignedClientCert mints a throwaway self-signed certificate/key pair for use as a TLS client
+// certificate in tests.
+func generateSelfSignedClientCert(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	assert.Nilf(t, err, "\n\n"+
+		"UT Name:  Generating the client key should NOT return an error.\n"+
+		"\033[32mExpected: <nil>\033[0m\n"+
+		"\033[31mActual:   %v\033[0m\n\n", err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tstsrv-test-client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	assert.Nilf(t, err, "\n\n"+
+		"UT Name:  Creating the client certificate should NOT return an error.\n"+
+		"\033[32mExpected: <nil>\033[0m\n"+
+		"\033[31mActual:   %v\033[0m\n\n", err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// UT: A Response.Body is executed as a template, with access to the request and the call count.
+func TestServer_TemplatedBody(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
+		"/vault/data/secret?version=1": {
+			{
+				Responses: []tstsrv.Response{
+					{
+						StatusCode: http.StatusOK,
+						Body:       `{"server":"{{.ServerURL}}","method":"{{.Request.Method}}","version":"{{.Request.Query.Get "version"}}","call":{{.CallCount}}}`,
+					},
+					{
+						StatusCode: http.StatusOK,
+						Body:       `{"call":{{.CallCount}}}`,
+					},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+
+	// SCENARIO #1.
+	t.Run("The template can reference the server URL, the request and the call count.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Get(srvURL + "/vault/data/secret?version=1")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The templated response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the templated response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		expected := `{"server":"` + srvURL + `","method":"GET","version":"1","call":1}`
+
+		assert.Equalf(t, string(body), expected, "\n\n"+
+			"UT Name:  The rendered body should substitute the server URL, method, query and call count.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", expected, string(body))
+	})
+
+	// SCENARIO #2.
+	t.Run("The call count increases with every subsequent call.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Get(srvURL + "/vault/data/secret?version=1")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The second templated response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the second templated response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), `{"call":2}`, "\n\n"+
+			"UT Name:  The second response should report the second call count.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", `{"call":2}`, string(body))
+	})
+}
+
+// UT: A broken Response.Body template fails loudly instead of serving the raw, unexecuted template source.
+func TestServer_TemplatedBody_BrokenTemplate(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(map[string][]tstsrv.RespConfiguration{
+		"/broken": {
+			{
+				Responses: []tstsrv.Response{
+					{StatusCode: http.StatusOK, Body: `{{(index .Request.Query "v") 0}}`},
+				},
+			},
+		},
+	})
+
+	defer srvFake.Close()
+
+	// ACT & ASSERT.
+	resp, err := http.Get(srvFake.URL() + "/broken")
+
+	assert.Nilf(t, err, "\n\n"+
+		"UT Name:  The request against a broken template should NOT return an error.\n"+
+		"\033[32mExpected: <nil>\033[0m\n"+
+		"\033[31mActual:   %v\033[0m\n\n", err)
+
+	assert.Equalf(t, resp.StatusCode, http.StatusInternalServerError, "\n\n"+
+		"UT Name:  A broken template should surface as a 500 status code instead of a silent 200.\n"+
+		"\033[32mExpected: %d\033[0m\n"+
+		"\033[31mActual:   %d\033[0m\n\n", http.StatusInternalServerError, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	assert.Nilf(t, err, "\n\n"+
+		"UT Name:  Reading the error response should NOT return an error.\n"+
+		"\033[32mExpected: <nil>\033[0m\n"+
+		"\033[31mActual:   %v\033[0m\n\n", err)
+
+	assert.Equalf(t, strings.Contains(string(body), "tstsrv: error rendering Response.Body template"), true, "\n\n"+
+		"UT Name:  The error response body should explain that the template failed to render.\n"+
+		"\033[32mExpected: %s\033[0m\n"+
+		"\033[31mActual:   %s\033[0m\n\n", "tstsrv: error rendering Response.Body template: ...", string(body))
+}
+
+// UT: Routes can be registered (and later extended) after construction via When(), and cleared via Clear()/ClearAll().
+func TestServer_When(t *testing.T) {
+	t.Parallel() // Enable parallel execution.
+
+	// FAKE SETUP.
+	srvFake := tstsrv.New(nil)
+
+	defer srvFake.Close()
+
+	// ARRANGE.
+	srvURL := srvFake.URL()
+
+	builder := srvFake.When("/login").
+		Method(http.MethodPost).
+		RespondWith(tstsrv.Response{StatusCode: http.StatusOK, Body: "token-1"})
+
+	// SCENARIO #1.
+	t.Run("A route registered with When()/RespondWith() is immediately servable.", func(t *testing.T) {
+		// ACT & ASSERT.
+		resp, err := http.Post(srvURL+"/login", "text/plain", nil)
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The request should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), "token-1", "\n\n"+
+			"UT Name:  The response body should match the registered response.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "token-1", string(body))
+	})
+
+	// SCENARIO #2.
+	t.Run("Then() appends a further response without losing the progress already made.", func(t *testing.T) {
+		// ARRANGE.
+		builder.Then(tstsrv.Response{StatusCode: http.StatusOK, Body: "token-2"})
+
+		// ACT & ASSERT.
+		resp, err := http.Post(srvURL+"/login", "text/plain", nil)
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The second request should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the second response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), "token-2", "\n\n"+
+			"UT Name:  The second response body should match the appended response.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "token-2", string(body))
+	})
+
+	// SCENARIO #3.
+	t.Run("Clear() removes the route, falling back to the 501 status code.", func(t *testing.T) {
+		// ACT.
+		srvFake.Clear("/login")
+
+		// ASSERT.
+		resp, err := http.Post(srvURL+"/login", "text/plain", nil)
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The request after Clear() should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, resp.StatusCode, http.StatusNotImplemented, "\n\n"+
+			"UT Name:  The request after Clear() should return the 501 status code.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", http.StatusNotImplemented, resp.StatusCode)
+
+		resp.Body.Close()
+	})
+
+	// SCENARIO #4.
+	t.Run("ClearAll() removes every route.", func(t *testing.T) {
+		// ARRANGE.
+		srvFake.When("/other").RespondWith(tstsrv.Response{StatusCode: http.StatusOK, Body: "other"})
+
+		// ACT.
+		srvFake.ClearAll()
+
+		// ASSERT.
+		resp, err := http.Get(srvURL + "/other")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The request after ClearAll() should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, resp.StatusCode, http.StatusNotImplemented, "\n\n"+
+			"UT Name:  The request after ClearAll() should return the 501 status code.\n"+
+			"\033[32mExpected: %d\033[0m\n"+
+			"\033[31mActual:   %d\033[0m\n\n", http.StatusNotImplemented, resp.StatusCode)
+
+		resp.Body.Close()
+	})
+
+	// SCENARIO #5.
+	t.Run("Then() on a builder whose route was cleared re-registers it instead of panicking.", func(t *testing.T) {
+		// ARRANGE.
+		staleBuilder := srvFake.When("/stale").RespondWith(tstsrv.Response{StatusCode: http.StatusOK, Body: "first"})
+
+		srvFake.ClearAll()
+
+		// ACT.
+		staleBuilder.Then(tstsrv.Response{StatusCode: http.StatusOK, Body: "second"})
+
+		// ASSERT.
+		resp, err := http.Get(srvURL + "/stale")
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  The request against the re-registered route should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		assert.Nilf(t, err, "\n\n"+
+			"UT Name:  Reading the re-registered route's response should NOT return an error.\n"+
+			"\033[32mExpected: <nil>\033[0m\n"+
+			"\033[31mActual:   %v\033[0m\n\n", err)
+
+		assert.Equalf(t, string(body), "first", "\n\n"+
+			"UT Name:  The re-registered route should serve its responses from the start.\n"+
+			"\033[32mExpected: %s\033[0m\n"+
+			"\033[31mActual:   %s\033[0m\n\n", "first", string(body))
+	})
+}